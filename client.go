@@ -0,0 +1,131 @@
+package apcupsd
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// DefaultPort is the default TCP port used by a NIS (Network Information
+// Server) to serve UPS status information.
+const DefaultPort = 3551
+
+// A Client is a connection to a NIS, used to retrieve UPS Status
+// information.
+type Client struct {
+	conn net.Conn
+
+	// OnRecord, if set, is called with the raw key and value of every
+	// "key : value" pair read from the NIS, before it is parsed into a
+	// Status. This is primarily useful for logging or debugging keys that
+	// aren't otherwise recognized.
+	OnRecord func(key, value string)
+}
+
+// Dial connects to a NIS using the given network (typically "tcp") and
+// address.
+func Dial(network, address string) (*Client, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{conn: conn}, nil
+}
+
+// DialTLS connects to a NIS the same way Dial does, but wraps the connection
+// in TLS. This is the usual way to reach a NIS that's only reachable through
+// a stunnel front end, since the NIS protocol itself has no authentication
+// or encryption of its own. config is passed to tls.Dial unmodified; a nil
+// config uses the system's default root CA pool with no client certificate.
+func DialTLS(network, address string, config *tls.Config) (*Client, error) {
+	conn, err := tls.Dial(network, address, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{conn: conn}, nil
+}
+
+// Close closes the connection to a NIS.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// SetDeadline sets the read and write deadlines for the underlying
+// connection to a NIS.
+func (c *Client) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+// Status retrieves the current Status of the UPS monitored by a NIS.
+func (c *Client) Status() (*Status, error) {
+	if err := c.writeRecord("status"); err != nil {
+		return nil, err
+	}
+
+	var s Status
+	for {
+		line, err := c.readRecord()
+		if err != nil {
+			return nil, err
+		}
+		if line == "" {
+			// A zero-length record terminates a multi-record response.
+			break
+		}
+
+		k, v, err := splitKV(line)
+		if err != nil {
+			return nil, fmt.Errorf("apcupsd: failed to parse %q: %v", line, err)
+		}
+
+		if c.OnRecord != nil {
+			c.OnRecord(k, v)
+		}
+
+		if err := s.setField(k, v); err != nil {
+			return nil, fmt.Errorf("apcupsd: failed to parse %q: %v", line, err)
+		}
+	}
+
+	return &s, nil
+}
+
+// writeRecord writes a single length-prefixed command to a NIS, per the NIS
+// wire protocol.
+func (c *Client) writeRecord(command string) error {
+	var length [2]byte
+	binary.BigEndian.PutUint16(length[:], uint16(len(command)))
+
+	if _, err := c.conn.Write(length[:]); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(c.conn, command)
+	return err
+}
+
+// readRecord reads a single length-prefixed record from a NIS. An empty
+// string indicates the end of a multi-record response.
+func (c *Client) readRecord() (string, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(c.conn, length[:]); err != nil {
+		return "", err
+	}
+
+	n := binary.BigEndian.Uint16(length[:])
+	if n == 0 {
+		return "", nil
+	}
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(c.conn, buf); err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
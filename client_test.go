@@ -0,0 +1,75 @@
+package apcupsd
+
+import (
+	"net"
+	"testing"
+)
+
+func TestClientStatus(t *testing.T) {
+	addr := newFakeNIS(t, [][]string{{
+		"STATUS   : ONLINE",
+		"LINEV    : 120.5 Volts",
+	}})
+
+	c, err := Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer c.Close()
+
+	s, err := c.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+
+	if s.Status != "ONLINE" || s.LineVoltage != 120.5 {
+		t.Errorf("Status() = %+v, want Status=ONLINE LineVoltage=120.5", s)
+	}
+}
+
+func TestClientStatusOnRecord(t *testing.T) {
+	addr := newFakeNIS(t, [][]string{{
+		"STATUS   : ONLINE",
+		"LINEV    : 120.5 Volts",
+	}})
+
+	c, err := Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer c.Close()
+
+	var got []string
+	c.OnRecord = func(k, v string) {
+		got = append(got, k+"="+v)
+	}
+
+	if _, err := c.Status(); err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+
+	want := []string{"STATUS=ONLINE", "LINEV=120.5 Volts"}
+	if len(got) != len(want) {
+		t.Fatalf("OnRecord calls = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("OnRecord call %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestClientStatusDialError(t *testing.T) {
+	// Bind a listener to learn an address nothing is using, then close it
+	// so connecting to it fails.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	if _, err := Dial("tcp", addr); err == nil {
+		t.Error("Dial() to a closed listener succeeded, want error")
+	}
+}
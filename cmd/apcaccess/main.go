@@ -0,0 +1,265 @@
+// Command apcaccess is a drop-in replacement for the upstream C apcaccess
+// utility, with additional output modes aimed at scripting and modern
+// tooling.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/tvoyle/apcupsd"
+	"github.com/tvoyle/apcupsd/metrics"
+)
+
+func main() {
+	var (
+		host    = flag.String("host", "localhost", "hostname or address of the NIS to query")
+		port    = flag.Int("port", apcupsd.DefaultPort, "port of the NIS to query")
+		timeout = flag.Duration("timeout", 5*time.Second, "timeout for querying the NIS once connected; connecting is not bounded by it")
+		output  = flag.String("o", "text", `output mode: "text", "json", "prom", "template", or "key"`)
+		tmplSrc = flag.String("template", "", `Go template to execute for each Status, used with -o template`)
+		watch   = flag.Duration("watch", 0, "if nonzero, re-poll the NIS at this interval and print deltas instead of exiting after one poll")
+	)
+	flag.Parse()
+
+	p, err := newPrinter(*output, *tmplSrc, flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "apcaccess: %v\n", err)
+		os.Exit(2)
+	}
+
+	addr := fmt.Sprintf("%s:%d", *host, *port)
+
+	if *watch <= 0 {
+		s, err := poll(addr, *timeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "apcaccess: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := p.print(os.Stdout, nil, s); err != nil {
+			fmt.Fprintf(os.Stderr, "apcaccess: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var prev *apcupsd.Status
+	for {
+		s, err := poll(addr, *timeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "apcaccess: %v\n", err)
+		} else {
+			if err := p.print(os.Stdout, prev, s); err != nil {
+				fmt.Fprintf(os.Stderr, "apcaccess: %v\n", err)
+			}
+			prev = s
+		}
+
+		time.Sleep(*watch)
+	}
+}
+
+// poll dials the NIS at addr and fetches its current Status. If timeout is
+// nonzero, it bounds the exchange once the connection is established; the
+// dial itself has no timeout.
+func poll(addr string, timeout time.Duration) (*apcupsd.Status, error) {
+	c, err := apcupsd.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	if timeout > 0 {
+		if err := c.SetDeadline(time.Now().Add(timeout)); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.Status()
+}
+
+// A printer renders a Status to an output mode selected by -o. prev is the
+// previously observed Status, or nil on the first poll or outside of
+// -watch; implementations may use it to print only the fields that
+// changed.
+type printer interface {
+	print(w io.Writer, prev, curr *apcupsd.Status) error
+}
+
+// newPrinter builds the printer selected by mode.
+func newPrinter(mode, tmplSrc string, args []string) (printer, error) {
+	switch mode {
+	case "", "text":
+		return textPrinter{}, nil
+	case "json":
+		return jsonPrinter{}, nil
+	case "prom":
+		return promPrinter{}, nil
+	case "template":
+		if tmplSrc == "" {
+			return nil, errors.New("-o template requires -template")
+		}
+		t, err := template.New("apcaccess").Parse(tmplSrc)
+		if err != nil {
+			return nil, err
+		}
+		return templatePrinter{tmpl: t}, nil
+	case "key":
+		if len(args) != 1 {
+			return nil, errors.New("-o key requires exactly one field name argument")
+		}
+		return keyPrinter{field: args[0]}, nil
+	default:
+		return nil, fmt.Errorf("unrecognized output mode %q", mode)
+	}
+}
+
+// textPrinter renders a Status as "Field: value" lines, one per exported
+// field, in the spirit of the upstream apcaccess's "KEY  : value" output.
+// When a previous Status is available, only changed fields are printed.
+type textPrinter struct{}
+
+func (textPrinter) print(w io.Writer, prev, curr *apcupsd.Status) error {
+	cv := reflect.ValueOf(*curr)
+	ct := cv.Type()
+
+	var pv reflect.Value
+	if prev != nil {
+		pv = reflect.ValueOf(*prev)
+	}
+
+	for i := 0; i < ct.NumField(); i++ {
+		name := ct.Field(i).Name
+		if name == "Extra" {
+			continue
+		}
+
+		cf := cv.Field(i)
+		if prev != nil {
+			pf := pv.Field(i)
+			if reflect.DeepEqual(pf.Interface(), cf.Interface()) {
+				continue
+			}
+			fmt.Fprintf(w, "%-28s: %s -> %s\n", name, formatValue(pf), formatValue(cf))
+			continue
+		}
+
+		fmt.Fprintf(w, "%-28s: %s\n", name, formatValue(cf))
+	}
+
+	for k, v := range curr.Extra {
+		if prev != nil && prev.Extra[k] == v {
+			continue
+		}
+		fmt.Fprintf(w, "%-28s: %s\n", k, v)
+	}
+
+	return nil
+}
+
+// formatValue renders a single Status field for textPrinter and keyPrinter.
+func formatValue(v reflect.Value) string {
+	switch val := v.Interface().(type) {
+	case time.Time:
+		if val.IsZero() {
+			return "N/A"
+		}
+		return val.Format(time.RFC3339)
+	case time.Duration:
+		return val.String()
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// jsonPrinter renders the full Status as JSON, with timestamps in RFC3339
+// and durations expressed in fractional seconds rather than nanoseconds.
+type jsonPrinter struct{}
+
+func (jsonPrinter) print(w io.Writer, _, curr *apcupsd.Status) error {
+	v := reflect.ValueOf(*curr)
+	t := v.Type()
+
+	out := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+
+		switch val := v.Field(i).Interface().(type) {
+		case time.Duration:
+			out[name] = val.Seconds()
+		case time.Time:
+			if val.IsZero() {
+				out[name] = nil
+			} else {
+				out[name] = val.Format(time.RFC3339)
+			}
+		case map[string]string:
+			if len(val) > 0 {
+				out[name] = val
+			}
+		default:
+			out[name] = val
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// promPrinter renders a single-shot Prometheus text exposition of curr,
+// using the same metric catalog as the apcupsd/metrics Collector.
+type promPrinter struct{}
+
+func (promPrinter) print(w io.Writer, _, curr *apcupsd.Status) error {
+	labels := fmt.Sprintf(`hostname=%q,model=%q,serial_number=%q`, curr.Hostname, curr.Model, curr.SerialNumber)
+
+	for _, m := range metrics.Collect(curr) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s{%s} %v\n", m.Name, m.Help, m.Name, m.Type, m.Name, labels, m.Value)
+	}
+
+	return nil
+}
+
+// templatePrinter renders curr using a user-supplied Go template.
+type templatePrinter struct {
+	tmpl *template.Template
+}
+
+func (p templatePrinter) print(w io.Writer, _, curr *apcupsd.Status) error {
+	return p.tmpl.Execute(w, curr)
+}
+
+// keyPrinter prints the raw value of a single named field, for scripting,
+// e.g. `apcaccess -o key LineVoltage`.
+type keyPrinter struct {
+	field string
+}
+
+func (p keyPrinter) print(w io.Writer, _, curr *apcupsd.Status) error {
+	v := reflect.ValueOf(*curr)
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		if strings.EqualFold(t.Field(i).Name, p.field) {
+			fmt.Fprintln(w, formatValue(v.Field(i)))
+			return nil
+		}
+	}
+
+	if val, ok := curr.Extra[p.field]; ok {
+		fmt.Fprintln(w, val)
+		return nil
+	}
+
+	return fmt.Errorf("no such field %q", p.field)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tvoyle/apcupsd"
+)
+
+func TestTextPrinterFullStatus(t *testing.T) {
+	s := &apcupsd.Status{Status: "ONLINE", LineVoltage: 120.5}
+
+	var buf bytes.Buffer
+	if err := (textPrinter{}).print(&buf, nil, s); err != nil {
+		t.Fatalf("print() error = %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "Status") || !strings.Contains(out, "ONLINE") {
+		t.Errorf("output missing Status field: %s", out)
+	}
+	if !strings.Contains(out, "120.5") {
+		t.Errorf("output missing LineVoltage value: %s", out)
+	}
+}
+
+func TestTextPrinterOnlyPrintsChangedFields(t *testing.T) {
+	prev := &apcupsd.Status{Status: "ONLINE", LineVoltage: 120.5}
+	curr := &apcupsd.Status{Status: "ONBATT", LineVoltage: 120.5}
+
+	var buf bytes.Buffer
+	if err := (textPrinter{}).print(&buf, prev, curr); err != nil {
+		t.Fatalf("print() error = %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "Status") {
+		t.Errorf("output missing changed Status field: %s", out)
+	}
+	if strings.Contains(out, "LineVoltage") {
+		t.Errorf("output contains unchanged LineVoltage field: %s", out)
+	}
+}
+
+func TestJSONPrinterDurationAsSeconds(t *testing.T) {
+	s := &apcupsd.Status{TimeLeft: 90 * time.Second}
+
+	var buf bytes.Buffer
+	if err := (jsonPrinter{}).print(&buf, nil, s); err != nil {
+		t.Fatalf("print() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), `"TimeLeft": 90`) {
+		t.Errorf("expected TimeLeft to be rendered as 90 seconds, got: %s", buf.String())
+	}
+}
+
+func TestJSONPrinterRFC3339Timestamp(t *testing.T) {
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := &apcupsd.Status{Date: date}
+
+	var buf bytes.Buffer
+	if err := (jsonPrinter{}).print(&buf, nil, s); err != nil {
+		t.Fatalf("print() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), date.Format(time.RFC3339)) {
+		t.Errorf("expected Date to be rendered as RFC3339, got: %s", buf.String())
+	}
+}
+
+func TestKeyPrinter(t *testing.T) {
+	s := &apcupsd.Status{LineVoltage: 120.5, Extra: map[string]string{"WIDGET": "42"}}
+
+	tests := []struct {
+		name    string
+		field   string
+		want    string
+		wantErr bool
+	}{
+		{"known field, exact case", "LineVoltage", "120.5", false},
+		{"known field, case-insensitive", "linevoltage", "120.5", false},
+		{"extra field", "WIDGET", "42", false},
+		{"unknown field", "NoSuchField", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := (keyPrinter{field: tt.field}).print(&buf, nil, s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("print() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("print() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+// Command apcupsd_exporter runs a Prometheus exporter that serves UPS
+// Status metrics scraped from an apcupsd NIS.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tvoyle/apcupsd/metrics"
+)
+
+func main() {
+	var (
+		nisAddr  = flag.String("nis.addr", "localhost:3551", "address of the NIS to scrape")
+		httpAddr = flag.String("http.addr", ":9099", "address to serve Prometheus metrics on")
+		timeout  = flag.Duration("nis.timeout", 5*time.Second, "timeout for each scrape of the NIS once connected; connection setup is not bounded by it")
+	)
+	flag.Parse()
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(metrics.NewCollector("tcp", *nisAddr, *timeout))
+
+	http.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+
+	log.Printf("apcupsd_exporter: serving metrics for %q on %q", *nisAddr, *httpAddr)
+	log.Fatal(http.ListenAndServe(*httpAddr, nil))
+}
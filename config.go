@@ -0,0 +1,173 @@
+package apcupsd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v3"
+)
+
+// A UPSConfig describes how a Manager should connect to and poll a single
+// UPS's NIS.
+type UPSConfig struct {
+	// Name uniquely identifies this UPS within a Config, and is used to key
+	// results returned by a Manager.
+	Name string `json:"name" yaml:"name" ini:"name"`
+
+	// Network and Address are passed to Dial, e.g. "tcp" and
+	// "ups1.example.com:3551".
+	Network string `json:"network" yaml:"network" ini:"network"`
+	Address string `json:"address" yaml:"address" ini:"address"`
+
+	// Interval is how often to poll this UPS. If zero, WatcherOptions' own
+	// default applies.
+	Interval time.Duration `json:"interval" yaml:"interval" ini:"interval"`
+
+	// Timeout bounds how long a single poll of this UPS, including
+	// connection setup, may take. If zero, no timeout is applied.
+	Timeout time.Duration `json:"timeout" yaml:"timeout" ini:"timeout"`
+
+	// ChargeThreshold configures WatcherOptions.ChargeThreshold for this
+	// UPS's alerting.
+	ChargeThreshold float64 `json:"charge_threshold" yaml:"charge_threshold" ini:"charge_threshold"`
+
+	// MaxRetries configures WatcherOptions.MaxRetries for this UPS. If
+	// zero, Manager.Run retries this UPS indefinitely.
+	MaxRetries int `json:"max_retries" yaml:"max_retries" ini:"max_retries"`
+
+	// TLSCert and TLSKey are paths to a PEM client certificate and private
+	// key presented to a stunnel-fronted NIS for mutual TLS. Leave both
+	// empty to connect without a client certificate.
+	TLSCert string `json:"tls_cert" yaml:"tls_cert" ini:"tls_cert"`
+	TLSKey  string `json:"tls_key" yaml:"tls_key" ini:"tls_key"`
+
+	// TLSCA is the path to a PEM CA certificate used to verify a
+	// stunnel-fronted NIS's server certificate, in place of the system root
+	// CA pool. Leave empty to use the system root CA pool.
+	TLSCA string `json:"tls_ca" yaml:"tls_ca" ini:"tls_ca"`
+
+	// TLSServerName overrides the server name used to verify a
+	// stunnel-fronted NIS's certificate, useful when Address doesn't match
+	// the name on the certificate (e.g. a stunnel listening on localhost).
+	// If empty, Address's host is used.
+	//
+	// Setting any of TLSCert, TLSKey, TLSCA, or TLSServerName causes the
+	// UPS to be dialed with DialTLS instead of Dial.
+	TLSServerName string `json:"tls_server_name" yaml:"tls_server_name" ini:"tls_server_name"`
+}
+
+// tlsConfig builds the *tls.Config described by u's TLS* fields, or nil if
+// none of them are set, in which case the UPS is dialed in plaintext.
+func (u UPSConfig) tlsConfig() (*tls.Config, error) {
+	if u.TLSCert == "" && u.TLSKey == "" && u.TLSCA == "" && u.TLSServerName == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{ServerName: u.TLSServerName}
+
+	if u.TLSCert != "" || u.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(u.TLSCert, u.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("apcupsd: failed to load TLS client certificate for %q: %v", u.Name, err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if u.TLSCA != "" {
+		pem, err := os.ReadFile(u.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("apcupsd: failed to read TLS CA certificate for %q: %v", u.Name, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("apcupsd: failed to parse TLS CA certificate for %q", u.Name)
+		}
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
+
+// A Config describes a collection of UPSes for a Manager to poll, e.g.
+// every UPS in a rack monitored by a single process.
+type Config struct {
+	UPSes []UPSConfig `json:"upses" yaml:"upses"`
+}
+
+// LoadConfigFile reads a Config from the file at path. The format is
+// selected by the file's extension: ".json" for JSON, ".yml" or ".yaml"
+// for YAML, and ".ini" for INI.
+func LoadConfigFile(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return LoadConfigJSON(b)
+	case ".yml", ".yaml":
+		return LoadConfigYAML(b)
+	case ".ini":
+		return LoadConfigINI(b)
+	default:
+		return nil, fmt.Errorf("apcupsd: unrecognized config file extension %q", ext)
+	}
+}
+
+// LoadConfigJSON parses a Config from JSON.
+func LoadConfigJSON(b []byte) (*Config, error) {
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// LoadConfigYAML parses a Config from YAML.
+func LoadConfigYAML(b []byte) (*Config, error) {
+	var c Config
+	if err := yaml.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// LoadConfigINI parses a Config from INI, à la go-ini. Each UPS is
+// described by its own section, named after the UPS, e.g.:
+//
+//	[ups1]
+//	address = ups1.example.com:3551
+//	interval = 10s
+func LoadConfigINI(b []byte) (*Config, error) {
+	f, err := ini.Load(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Config
+	for _, sec := range f.Sections() {
+		if sec.Name() == ini.DefaultSection {
+			continue
+		}
+
+		u := UPSConfig{Name: sec.Name()}
+		if err := sec.MapTo(&u); err != nil {
+			return nil, fmt.Errorf("apcupsd: failed to parse section %q: %v", sec.Name(), err)
+		}
+
+		c.UPSes = append(c.UPSes, u)
+	}
+
+	return &c, nil
+}
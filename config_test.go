@@ -0,0 +1,97 @@
+package apcupsd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadConfigJSON(t *testing.T) {
+	data := []byte(`{
+		"upses": [
+			{
+				"name": "ups1",
+				"network": "tcp",
+				"address": "ups1.example.com:3551",
+				"interval": 10000000000,
+				"charge_threshold": 50
+			}
+		]
+	}`)
+
+	c, err := LoadConfigJSON(data)
+	if err != nil {
+		t.Fatalf("LoadConfigJSON() error = %v", err)
+	}
+
+	if len(c.UPSes) != 1 {
+		t.Fatalf("UPSes = %d, want 1", len(c.UPSes))
+	}
+
+	u := c.UPSes[0]
+	if u.Name != "ups1" || u.Address != "ups1.example.com:3551" || u.Interval != 10*time.Second || u.ChargeThreshold != 50 {
+		t.Errorf("unexpected UPSConfig: %+v", u)
+	}
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	data := []byte(`
+upses:
+  - name: ups1
+    network: tcp
+    address: ups1.example.com:3551
+    interval: 10s
+    charge_threshold: 50
+`)
+
+	c, err := LoadConfigYAML(data)
+	if err != nil {
+		t.Fatalf("LoadConfigYAML() error = %v", err)
+	}
+
+	if len(c.UPSes) != 1 {
+		t.Fatalf("UPSes = %d, want 1", len(c.UPSes))
+	}
+
+	u := c.UPSes[0]
+	if u.Name != "ups1" || u.Address != "ups1.example.com:3551" || u.Interval != 10*time.Second || u.ChargeThreshold != 50 {
+		t.Errorf("unexpected UPSConfig: %+v", u)
+	}
+}
+
+func TestLoadConfigINI(t *testing.T) {
+	data := []byte(`
+[ups1]
+address = ups1.example.com:3551
+interval = 10s
+charge_threshold = 50
+
+[ups2]
+address = ups2.example.com:3551
+`)
+
+	c, err := LoadConfigINI(data)
+	if err != nil {
+		t.Fatalf("LoadConfigINI() error = %v", err)
+	}
+
+	if len(c.UPSes) != 2 {
+		t.Fatalf("UPSes = %d, want 2", len(c.UPSes))
+	}
+
+	byName := make(map[string]UPSConfig, len(c.UPSes))
+	for _, u := range c.UPSes {
+		byName[u.Name] = u
+	}
+
+	u1, ok := byName["ups1"]
+	if !ok {
+		t.Fatalf("missing UPSConfig for %q", "ups1")
+	}
+	if u1.Address != "ups1.example.com:3551" || u1.Interval != 10*time.Second || u1.ChargeThreshold != 50 {
+		t.Errorf("unexpected UPSConfig for ups1: %+v", u1)
+	}
+
+	if _, ok := byName["ups2"]; !ok {
+		t.Fatalf("missing UPSConfig for %q", "ups2")
+	}
+}
@@ -0,0 +1,102 @@
+package apcupsd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// A Manager fans out concurrent polling across every UPS described by a
+// Config and aggregates their Status values, keyed by UPSConfig.Name. It
+// replaces the one-connection-per-call model of Client with something
+// suited to monitoring a whole rack of UPSes from a single process.
+type Manager struct {
+	watchers map[string]*Watcher
+}
+
+// NewManager creates a Manager that will poll every UPS in cfg once Run is
+// called. It reports an error if any UPSConfig's TLS fields describe a
+// client certificate or CA certificate that can't be loaded.
+func NewManager(cfg Config) (*Manager, error) {
+	m := &Manager{
+		watchers: make(map[string]*Watcher, len(cfg.UPSes)),
+	}
+
+	for _, u := range cfg.UPSes {
+		network := u.Network
+		if network == "" {
+			network = "tcp"
+		}
+
+		tlsConfig, err := u.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		m.watchers[u.Name] = NewWatcher(network, u.Address, WatcherOptions{
+			Interval:        u.Interval,
+			Timeout:         u.Timeout,
+			ChargeThreshold: u.ChargeThreshold,
+			MaxRetries:      u.MaxRetries,
+			ReplayLatest:    true,
+			TLS:             tlsConfig,
+		})
+	}
+
+	return m, nil
+}
+
+// Run polls every configured UPS concurrently until ctx is canceled, or
+// until one of them exhausts its UPSConfig.MaxRetries and gives up. Run
+// blocks until all polling has stopped, then returns the first such error,
+// or ctx.Err() if every Watcher stopped only because ctx was canceled.
+func (m *Manager) Run(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(m.watchers))
+
+	for _, w := range m.watchers {
+		wg.Add(1)
+		go func(w *Watcher) {
+			defer wg.Done()
+			if err := w.Run(runCtx); err != nil && runCtx.Err() == nil {
+				errs <- err
+				cancel()
+			}
+		}(w)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	if err, ok := <-errs; ok {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// Statuses returns the most recently observed Status for every configured
+// UPS, keyed by name. An entry is nil until its UPS has completed at least
+// one successful poll.
+func (m *Manager) Statuses() map[string]*Status {
+	out := make(map[string]*Status, len(m.watchers))
+	for name, w := range m.watchers {
+		out[name] = w.Latest()
+	}
+
+	return out
+}
+
+// Subscribe registers a subscriber for Events from the named UPS. It
+// reports an error if name does not match any UPS known to the Manager.
+func (m *Manager) Subscribe(ctx context.Context, name string) (<-chan Event, error) {
+	w, ok := m.watchers[name]
+	if !ok {
+		return nil, fmt.Errorf("apcupsd: no configured UPS named %q", name)
+	}
+
+	return w.Subscribe(ctx), nil
+}
@@ -0,0 +1,97 @@
+package apcupsd
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestManagerRunPollsEveryUPS(t *testing.T) {
+	addr1 := newFakeNIS(t, [][]string{{"STATUS   : ONLINE", "HOSTNAME : ups1"}})
+	addr2 := newFakeNIS(t, [][]string{{"STATUS   : ONLINE", "HOSTNAME : ups2"}})
+
+	cfg := Config{UPSes: []UPSConfig{
+		{Name: "ups1", Network: "tcp", Address: addr1, Interval: 10 * time.Millisecond},
+		{Name: "ups2", Network: "tcp", Address: addr2, Interval: 10 * time.Millisecond},
+	}}
+
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	runDone := make(chan error, 1)
+	go func() { runDone <- m.Run(ctx) }()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		statuses := m.Statuses()
+		if statuses["ups1"] != nil && statuses["ups2"] != nil {
+			if statuses["ups1"].Hostname == "ups1" && statuses["ups2"].Hostname == "ups2" {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for both UPSes to report a Status: %+v", statuses)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	<-runDone
+}
+
+func TestManagerSubscribeUnknownUPS(t *testing.T) {
+	m, err := NewManager(Config{})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	if _, err := m.Subscribe(context.Background(), "no-such-ups"); err == nil {
+		t.Error("Subscribe() error = nil, want error for an unconfigured UPS name")
+	}
+}
+
+func TestManagerSubscribeDeliversEvents(t *testing.T) {
+	addr := newFakeNIS(t, [][]string{
+		{"STATUS   : ONLINE"},
+		{"STATUS   : ONBATT"},
+	})
+
+	m, err := NewManager(Config{UPSes: []UPSConfig{
+		{Name: "ups1", Network: "tcp", Address: addr, Interval: 10 * time.Millisecond},
+	}})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	sub, err := m.Subscribe(ctx, "ups1")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	runDone := make(chan error, 1)
+	go func() { runDone <- m.Run(ctx) }()
+
+	for {
+		select {
+		case ev, ok := <-sub:
+			if !ok {
+				t.Fatal("subscriber channel closed before observing EventOnBattery")
+			}
+			if ev.Type == EventOnBattery {
+				cancel()
+				<-runDone
+				return
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for EventOnBattery")
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Package metrics provides a Prometheus collector that exposes UPS Status
+// values scraped from an apcupsd NIS.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tvoyle/apcupsd"
+)
+
+// labelNames are the labels attached to every metric exposed by a
+// Collector.
+var labelNames = []string{"hostname", "model", "serial_number"}
+
+// A Collector is a prometheus.Collector that scrapes a Status from a NIS
+// each time Collect is called. Like Watcher.poll, it dials a fresh
+// connection per scrape and closes it afterward, since a NIS serves one
+// command per connection.
+type Collector struct {
+	network, address string
+	timeout          time.Duration
+
+	descs map[string]*prometheus.Desc
+}
+
+// NewCollector creates a Collector that scrapes the NIS at address, using
+// network (typically "tcp"). If timeout is nonzero, it bounds each scrape
+// once the connection is established; dialing the NIS is not bounded by it.
+func NewCollector(network, address string, timeout time.Duration) *Collector {
+	c := &Collector{
+		network: network,
+		address: address,
+		timeout: timeout,
+		descs:   make(map[string]*prometheus.Desc),
+	}
+
+	// The metric catalog's names and help text don't depend on a Status'
+	// values, so build the Descs once up front from a zero Status.
+	for _, m := range Collect(&apcupsd.Status{}) {
+		c.descs[m.Name] = prometheus.NewDesc(m.Name, m.Help, labelNames, nil)
+	}
+
+	return c
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descs {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector. It dials the NIS, fetches its
+// current Status, and emits it as a set of metrics. If the scrape fails, a
+// single invalid metric is sent describing the error.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	s, err := c.scrape()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(prometheus.NewInvalidDesc(err), err)
+		return
+	}
+
+	labels := []string{s.Hostname, s.Model, s.SerialNumber}
+
+	for _, m := range Collect(s) {
+		valueType := prometheus.GaugeValue
+		if m.Type == Counter {
+			valueType = prometheus.CounterValue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.descs[m.Name], valueType, m.Value, labels...)
+	}
+}
+
+// scrape dials the NIS, fetches its current Status, and closes the
+// connection.
+func (c *Collector) scrape() (*apcupsd.Status, error) {
+	client, err := apcupsd.Dial(c.network, c.address)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	if c.timeout > 0 {
+		if err := client.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+			return nil, err
+		}
+	}
+
+	return client.Status()
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newFakeNIS starts a TCP listener that behaves like a minimal NIS: for each
+// incoming connection, it reads one length-prefixed command, ignores it, and
+// writes back lines as length-prefixed records followed by a zero-length
+// terminator record. It's closed automatically when the test ends.
+func newFakeNIS(t *testing.T, lines []string) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeNIS(conn, lines)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+// serveFakeNIS handles a single connection to a newFakeNIS listener.
+func serveFakeNIS(conn net.Conn, lines []string) {
+	defer conn.Close()
+
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return
+	}
+	n := binary.BigEndian.Uint16(length[:])
+	cmd := make([]byte, n)
+	if _, err := io.ReadFull(conn, cmd); err != nil {
+		return
+	}
+
+	for _, line := range lines {
+		binary.BigEndian.PutUint16(length[:], uint16(len(line)))
+		if _, err := conn.Write(length[:]); err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, line); err != nil {
+			return
+		}
+	}
+
+	binary.BigEndian.PutUint16(length[:], 0)
+	conn.Write(length[:])
+}
+
+func TestCollectorDescribe(t *testing.T) {
+	c := NewCollector("tcp", "127.0.0.1:0", 0)
+
+	ch := make(chan *prometheus.Desc)
+	go func() {
+		c.Describe(ch)
+		close(ch)
+	}()
+
+	var count int
+	for range ch {
+		count++
+	}
+
+	if want := len(c.descs); count != want {
+		t.Errorf("Describe() sent %d Descs, want %d", count, want)
+	}
+}
+
+func TestCollectorCollect(t *testing.T) {
+	addr := newFakeNIS(t, []string{
+		"STATUS   : ONLINE",
+		"LINEV    : 120.5 Volts",
+		"HOSTNAME : ups1",
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewCollector("tcp", addr, time.Second))
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+
+	var found bool
+	for _, mf := range mfs {
+		if mf.GetName() != "apcupsd_line_voltage_volts" {
+			continue
+		}
+		found = true
+
+		if len(mf.Metric) != 1 {
+			t.Fatalf("apcupsd_line_voltage_volts has %d samples, want 1", len(mf.Metric))
+		}
+		if got := mf.Metric[0].GetGauge().GetValue(); got != 120.5 {
+			t.Errorf("apcupsd_line_voltage_volts = %v, want 120.5", got)
+		}
+
+		var gotHostname string
+		for _, l := range mf.Metric[0].GetLabel() {
+			if l.GetName() == "hostname" {
+				gotHostname = l.GetValue()
+			}
+		}
+		if gotHostname != "ups1" {
+			t.Errorf("hostname label = %q, want %q", gotHostname, "ups1")
+		}
+	}
+	if !found {
+		t.Fatal("apcupsd_line_voltage_volts metric not found")
+	}
+}
+
+func TestCollectorCollectScrapeError(t *testing.T) {
+	// Bind a listener to learn an address nothing is using, then close it
+	// so the scrape fails to dial.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewCollector("tcp", addr, time.Second))
+
+	if _, err := reg.Gather(); err == nil {
+		t.Error("Gather() error = nil, want error when the NIS is unreachable")
+	}
+}
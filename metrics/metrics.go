@@ -0,0 +1,77 @@
+package metrics
+
+import "github.com/tvoyle/apcupsd"
+
+// A Type identifies how a Metric's value should be interpreted by a
+// Prometheus-compatible consumer.
+type Type string
+
+// Possible Type values for a Metric.
+const (
+	// Gauge indicates a value that can arbitrarily go up or down.
+	Gauge Type = "gauge"
+
+	// Counter indicates a value that only ever increases.
+	Counter Type = "counter"
+)
+
+// A Metric is a single named value derived from a Status. It is the shared
+// representation used both by Collector, for exposing Status as a
+// prometheus.Collector, and by any other caller that wants to render a
+// one-off Status as Prometheus metrics without standing up a Collector,
+// such as apcaccess's "-o prom" output mode.
+type Metric struct {
+	Name  string
+	Help  string
+	Type  Type
+	Value float64
+}
+
+// Collect returns the full catalog of metrics derived from s. Every
+// numeric, integer, duration, and boolean field on Status is represented:
+// durations are reported in seconds, and percentages are reported as
+// ratios between 0 and 1.
+func Collect(s *apcupsd.Status) []Metric {
+	return []Metric{
+		{"apcupsd_line_voltage_volts", "Current input line voltage, in volts.", Gauge, s.LineVoltage},
+		{"apcupsd_load_ratio", "Current UPS load, as a ratio of capacity between 0 and 1.", Gauge, s.LoadPercent / 100},
+		{"apcupsd_battery_charge_ratio", "Current battery charge, as a ratio of capacity between 0 and 1.", Gauge, s.BatteryChargePercent / 100},
+		{"apcupsd_time_left_seconds", "Estimated runtime remaining on battery power, in seconds.", Gauge, s.TimeLeft.Seconds()},
+		{"apcupsd_minimum_battery_charge_ratio", "Minimum battery charge before a forced shutdown, as a ratio of capacity between 0 and 1.", Gauge, s.MinimumBatteryChargePercent / 100},
+		{"apcupsd_minimum_time_left_seconds", "Minimum runtime remaining before a forced shutdown, in seconds.", Gauge, s.MinimumTimeLeft.Seconds()},
+		{"apcupsd_maximum_time_seconds", "Maximum time to run on battery power before a forced shutdown, in seconds.", Gauge, s.MaximumTime.Seconds()},
+		{"apcupsd_low_transfer_voltage_volts", "Minimum line voltage before switching to battery power, in volts.", Gauge, s.LowTransferVoltage},
+		{"apcupsd_high_transfer_voltage_volts", "Maximum line voltage before switching to battery power, in volts.", Gauge, s.HighTransferVoltage},
+		{"apcupsd_alarm_delay_seconds", "Delay before the alarm sounds after going on battery power, in seconds.", Gauge, s.AlarmDel.Seconds()},
+		{"apcupsd_battery_voltage_volts", "Current battery voltage, in volts.", Gauge, s.BatteryVoltage},
+		{"apcupsd_transfers_total", "Total number of transfers to battery power.", Counter, float64(s.NumberTransfers)},
+		{"apcupsd_time_on_battery_seconds", "Time spent on battery power since the last transfer, in seconds.", Gauge, s.TimeOnBattery.Seconds()},
+		{"apcupsd_cumulative_time_on_battery_seconds", "Total time spent on battery power since apcupsd started.", Counter, s.CumulativeTimeOnBattery.Seconds()},
+		{"apcupsd_selftest_result", "Whether the most recent self test passed (1) or failed (0).", Gauge, boolValue(s.Selftest)},
+		{"apcupsd_nominal_input_voltage_volts", "Nominal input line voltage, in volts.", Gauge, s.NominalInputVoltage},
+		{"apcupsd_nominal_battery_voltage_volts", "Nominal battery voltage, in volts.", Gauge, s.NominalBatteryVoltage},
+		{"apcupsd_nominal_power_watts", "Nominal power rating, in watts.", Gauge, float64(s.NominalPower)},
+		{"apcupsd_internal_temperature_celsius", "Internal temperature, in degrees Celsius.", Gauge, s.InternalTemp},
+		{"apcupsd_output_voltage_volts", "Current output voltage, in volts.", Gauge, s.OutputVoltage},
+		{"apcupsd_line_frequency_hertz", "Current input line frequency, in hertz.", Gauge, s.LineFrequency},
+		{"apcupsd_maximum_line_voltage_volts", "Maximum input line voltage observed, in volts.", Gauge, s.MaximumLineVoltage},
+		{"apcupsd_minimum_line_voltage_volts", "Minimum input line voltage observed, in volts.", Gauge, s.MinimumLineVoltage},
+		{"apcupsd_wake_delay_seconds", "Delay before waking from a shutdown, in seconds.", Gauge, s.WakeDelay},
+		{"apcupsd_shutdown_delay_seconds", "Delay before shutting down on battery power, in seconds.", Gauge, s.ShutdownDelay},
+		{"apcupsd_low_battery_delay_seconds", "Delay before the low battery signal is raised, in seconds.", Gauge, s.LowBatteryDelay},
+		{"apcupsd_restore_percent_ratio", "Battery charge required before restoring power, as a ratio of capacity between 0 and 1.", Gauge, s.RestorePercent / 100},
+		{"apcupsd_selftest_interval_days", "Configured interval between automatic self tests, in days.", Gauge, float64(s.SelfTestInterval)},
+		{"apcupsd_nominal_output_voltage_volts", "Nominal output voltage, in volts.", Gauge, s.NominalOutputVoltage},
+		{"apcupsd_external_batteries", "Number of external battery packs attached.", Gauge, float64(s.ExternalBatteries)},
+		{"apcupsd_bad_batteries", "Number of external battery packs reporting bad.", Gauge, float64(s.BadBatteries)},
+		{"apcupsd_on_battery", "Whether the UPS is currently running on battery power (1) or line power (0).", Gauge, boolValue(s.OnBattery())},
+	}
+}
+
+// boolValue converts b into the 0/1 value expected by a Prometheus gauge.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tvoyle/apcupsd"
+)
+
+func TestCollect(t *testing.T) {
+	s := &apcupsd.Status{
+		LineVoltage:          120.5,
+		BatteryChargePercent: 100,
+		TimeLeft:             30 * time.Minute,
+		NumberTransfers:      3,
+		Status:               "ONBATT",
+	}
+
+	metrics := Collect(s)
+
+	want := map[string]float64{
+		"apcupsd_line_voltage_volts":   120.5,
+		"apcupsd_battery_charge_ratio": 1,
+		"apcupsd_time_left_seconds":    1800,
+		"apcupsd_transfers_total":      3,
+		"apcupsd_on_battery":           1,
+	}
+
+	got := make(map[string]float64, len(metrics))
+	for _, m := range metrics {
+		got[m.Name] = m.Value
+	}
+
+	for name, value := range want {
+		v, ok := got[name]
+		if !ok {
+			t.Errorf("missing metric %q", name)
+			continue
+		}
+		if v != value {
+			t.Errorf("metric %q = %v, want %v", name, v, value)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package apcupsd
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+)
+
+// newFakeNIS starts a TCP listener that behaves like a minimal NIS: for each
+// incoming connection, it reads one length-prefixed command, ignores it, and
+// writes back responses[i] (clamped to the last entry) as length-prefixed
+// records followed by a zero-length terminator record, where i is the
+// 0-based index of the connection. It's closed automatically when the test
+// ends.
+func newFakeNIS(t *testing.T, responses [][]string) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var n int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			i := int(atomic.AddInt32(&n, 1)) - 1
+			if i >= len(responses) {
+				i = len(responses) - 1
+			}
+			go serveFakeNIS(conn, responses[i])
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+// serveFakeNIS handles a single connection to a newFakeNIS listener.
+func serveFakeNIS(conn net.Conn, lines []string) {
+	defer conn.Close()
+
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return
+	}
+	n := binary.BigEndian.Uint16(length[:])
+	cmd := make([]byte, n)
+	if _, err := io.ReadFull(conn, cmd); err != nil {
+		return
+	}
+
+	for _, line := range lines {
+		binary.BigEndian.PutUint16(length[:], uint16(len(line)))
+		if _, err := conn.Write(length[:]); err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, line); err != nil {
+			return
+		}
+	}
+
+	binary.BigEndian.PutUint16(length[:], 0)
+	conn.Write(length[:])
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -83,57 +84,77 @@ type Status struct {
 	NominalOutputVoltage        float64
 	ExternalBatteries           int
 	BadBatteries                int
-}
 
-// parseKV parses an input key/value string in "key : value" format, and sets
-// the appropriate struct field from the input data.
-func (s *Status) parseKV(kv string) error {
-	sp := strings.SplitN(kv, ":", 2)
-	if len(sp) != 2 {
-		return errInvalidKeyValuePair
-	}
+	// Extra holds the raw value of any key emitted by a NIS that is not
+	// recognized by a registered FieldSetter. It allows callers to inspect
+	// keys from non-stock apcupsd builds or vendor-specific firmware without
+	// losing them.
+	Extra map[string]string
+}
 
-	k := strings.TrimSpace(sp[0])
-	v := strings.TrimSpace(sp[1])
+// A FieldSetter parses a raw NIS value and applies it to a Status. Setters
+// registered with RegisterField are invoked with the value half of a
+// "key : value" pair; the key itself is only used to select the setter.
+type FieldSetter func(s *Status, value string) error
 
-	// Attempt to match various common data types.
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]FieldSetter{}
+)
 
-	if match := s.parseKVString(k, v); match {
-		return nil
-	}
+// RegisterField associates key with setter, so that a future "key : value"
+// pair parsed from a NIS will invoke setter to populate a Status. Matching
+// is case-insensitive. This allows users to add support for keys emitted by
+// non-stock apcupsd builds or SmartUPS models without forking the package.
+//
+// Registering a key that is already registered, including one of the
+// package's built-in keys, replaces the existing FieldSetter.
+func RegisterField(key string, setter FieldSetter) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToUpper(key)] = setter
+}
 
-	if match, err := s.parseKVFloat(k, v); match {
+// parseKV parses an input key/value string in "key : value" format, and sets
+// the appropriate struct field from the input data. If no FieldSetter is
+// registered for the key, the raw value is instead recorded in s.Extra.
+func (s *Status) parseKV(kv string) error {
+	k, v, err := splitKV(kv)
+	if err != nil {
 		return err
 	}
 
-	if match, err := s.parseKVTime(k, v); match {
-		return err
-	}
+	return s.setField(k, v)
+}
 
-	if match, err := s.parseKVDuration(k, v); match {
-		return err
+// setField looks up the FieldSetter registered for k, case-insensitively,
+// and invokes it with v. If no FieldSetter is registered, v is recorded in
+// s.Extra instead.
+func (s *Status) setField(k, v string) error {
+	registryMu.RLock()
+	setter, ok := registry[strings.ToUpper(k)]
+	registryMu.RUnlock()
+
+	if !ok {
+		if s.Extra == nil {
+			s.Extra = make(map[string]string)
+		}
+		s.Extra[k] = v
+		return nil
 	}
 
-	// Attempt to match uncommon data types.
-
-	var err error
-	switch k {
-	case keyNumXfers:
-		s.NumberTransfers, err = strconv.Atoi(v)
-	case keySTestI:
-		s.SelfTestInterval, err = strconv.Atoi(v)
-	case keyExtBatts:
-		s.ExternalBatteries, err = strconv.Atoi(v)
-	case keyBadBatts:
-		s.BadBatteries, err = strconv.Atoi(v)
-	case keyNomPower:
-		f := strings.SplitN(v, " ", 2)
-		s.NominalPower, err = strconv.Atoi(f[0])
-	case keySelftest:
-		s.Selftest = v == "YES"
+	return setter(s, v)
+}
+
+// splitKV splits an input string in "key : value" format into its key and
+// value halves.
+func splitKV(kv string) (key string, value string, err error) {
+	sp := strings.SplitN(kv, ":", 2)
+	if len(sp) != 2 {
+		return "", "", errInvalidKeyValuePair
 	}
 
-	return err
+	return strings.TrimSpace(sp[0]), strings.TrimSpace(sp[1]), nil
 }
 
 // List of keys sent by a NIS, used to map values to Status fields.
@@ -197,170 +218,182 @@ const (
 	keyBadBatts      = "BADBATTS"
 )
 
-// parseKVString parses a simple string into the appropriate Status field. It
-// returns true if a field was matched, and false if not.
-func (s *Status) parseKVString(k string, v string) bool {
-	switch k {
-	case keyAPC:
-		s.APC = v
-	case keyHostname:
-		s.Hostname = v
-	case keyVersion:
-		s.Version = v
-	case keyUPSName:
-		s.UPSName = v
-	case keyCable:
-		s.Cable = v
-	case keyDriver:
-		s.Driver = v
-	case keyUPSMode:
-		s.UPSMode = v
-	case keyModel:
-		s.Model = v
-	case keyStatus:
-		s.Status = v
-	case keySense:
-		s.Sense = v
-	case keyLastXfer:
-		s.LastTransfer = v
-	case keyStatFlag:
-		s.StatusFlags = v
-	case keySerialNo:
-		s.SerialNumber = v
-	case keyBattDate:
-		s.BatteryDate = v
-	case keyFirmware:
-		s.Firmware = v
-	case keyManDate:
-		s.ManufactureDate = v
-	case keyDIPSw:
-		s.DIPSwitches = v
-	case keyReg1:
-		s.Register1 = v
-	case keyReg2:
-		s.Register2 = v
-	case keyReg3:
-		s.Register3 = v
-	default:
-		return false
-	}
-
-	return true
-}
-
-// parseKVFloat parses a float64 value into the appropriate Status field. It
-// returns true if a field was matched, and false if not.
-func (s *Status) parseKVFloat(k string, v string) (bool, error) {
-	f := strings.SplitN(v, " ", 2)
-
-	// Save repetition for function calls.
-	parse := func() (float64, error) {
-		return strconv.ParseFloat(f[0], 64)
-	}
+func init() {
+	// String fields.
+	stringField(keyAPC, func(s *Status) *string { return &s.APC })
+	stringField(keyHostname, func(s *Status) *string { return &s.Hostname })
+	stringField(keyVersion, func(s *Status) *string { return &s.Version })
+	stringField(keyUPSName, func(s *Status) *string { return &s.UPSName })
+	stringField(keyCable, func(s *Status) *string { return &s.Cable })
+	stringField(keyDriver, func(s *Status) *string { return &s.Driver })
+	stringField(keyUPSMode, func(s *Status) *string { return &s.UPSMode })
+	stringField(keyModel, func(s *Status) *string { return &s.Model })
+	stringField(keyStatus, func(s *Status) *string { return &s.Status })
+	stringField(keySense, func(s *Status) *string { return &s.Sense })
+	stringField(keyLastXfer, func(s *Status) *string { return &s.LastTransfer })
+	stringField(keyStatFlag, func(s *Status) *string { return &s.StatusFlags })
+	stringField(keySerialNo, func(s *Status) *string { return &s.SerialNumber })
+	stringField(keyBattDate, func(s *Status) *string { return &s.BatteryDate })
+	stringField(keyFirmware, func(s *Status) *string { return &s.Firmware })
+	stringField(keyManDate, func(s *Status) *string { return &s.ManufactureDate })
+	stringField(keyDIPSw, func(s *Status) *string { return &s.DIPSwitches })
+	stringField(keyReg1, func(s *Status) *string { return &s.Register1 })
+	stringField(keyReg2, func(s *Status) *string { return &s.Register2 })
+	stringField(keyReg3, func(s *Status) *string { return &s.Register3 })
+
+	// Float fields; the NIS sends these with a trailing unit, e.g.
+	// "123.4 Volts", so only the first space-separated token is parsed.
+	floatField(keyLineV, func(s *Status) *float64 { return &s.LineVoltage })
+	floatField(keyLoadPct, func(s *Status) *float64 { return &s.LoadPercent })
+	floatField(keyBCharge, func(s *Status) *float64 { return &s.BatteryChargePercent })
+	floatField(keyMBattChg, func(s *Status) *float64 { return &s.MinimumBatteryChargePercent })
+	floatField(keyLoTrans, func(s *Status) *float64 { return &s.LowTransferVoltage })
+	floatField(keyHiTrans, func(s *Status) *float64 { return &s.HighTransferVoltage })
+	floatField(keyBattV, func(s *Status) *float64 { return &s.BatteryVoltage })
+	floatField(keyNomInV, func(s *Status) *float64 { return &s.NominalInputVoltage })
+	floatField(keyNomBattV, func(s *Status) *float64 { return &s.NominalBatteryVoltage })
+	floatField(keyITemp, func(s *Status) *float64 { return &s.InternalTemp })
+	floatField(keyOutV, func(s *Status) *float64 { return &s.OutputVoltage })
+	floatField(keyLineFrequency, func(s *Status) *float64 { return &s.LineFrequency })
+	floatField(keyMaxLineV, func(s *Status) *float64 { return &s.MaximumLineVoltage })
+	floatField(keyMinLineV, func(s *Status) *float64 { return &s.MinimumLineVoltage })
+	floatField(keyDWake, func(s *Status) *float64 { return &s.WakeDelay })
+	floatField(keyDShutD, func(s *Status) *float64 { return &s.ShutdownDelay })
+	floatField(keyDLowBatt, func(s *Status) *float64 { return &s.LowBatteryDelay })
+	floatField(keyRetPct, func(s *Status) *float64 { return &s.RestorePercent })
+	floatField(keyNomOutV, func(s *Status) *float64 { return &s.NominalOutputVoltage })
+
+	// Time fields.
+	timeField(keyDate, func(s *Status) *time.Time { return &s.Date })
+	timeField(keyStartTime, func(s *Status) *time.Time { return &s.StartTime })
+	timeField(keyXOnBat, func(s *Status) *time.Time { return &s.XOnBattery })
+	timeField(keyXOffBat, func(s *Status) *time.Time { return &s.XOffBattery })
+	timeField(keyLastStest, func(s *Status) *time.Time { return &s.LastSelftest })
+	timeField(keyEndAPC, func(s *Status) *time.Time { return &s.EndAPC })
+
+	// Duration fields.
+	durationField(keyTimeLeft, func(s *Status) *time.Duration { return &s.TimeLeft })
+	durationField(keyMinTimeL, func(s *Status) *time.Duration { return &s.MinimumTimeLeft })
+	durationField(keyMaxTime, func(s *Status) *time.Duration { return &s.MaximumTime })
+	durationField(keyTOnBatt, func(s *Status) *time.Duration { return &s.TimeOnBattery })
+	durationField(keyCumOnBatt, func(s *Status) *time.Duration { return &s.CumulativeTimeOnBattery })
+
+	RegisterField(keyAlarmDel, func(s *Status, v string) error {
+		// No alarm delay configured.
+		if v == "No alarm" {
+			return nil
+		}
 
-	var err error
-	switch k {
-	case keyLineV:
-		s.LineVoltage, err = parse()
-	case keyLoadPct:
-		s.LoadPercent, err = parse()
-	case keyBCharge:
-		s.BatteryChargePercent, err = parse()
-	case keyMBattChg:
-		s.MinimumBatteryChargePercent, err = parse()
-	case keyLoTrans:
-		s.LowTransferVoltage, err = parse()
-	case keyHiTrans:
-		s.HighTransferVoltage, err = parse()
-	case keyBattV:
-		s.BatteryVoltage, err = parse()
-	case keyNomInV:
-		s.NominalInputVoltage, err = parse()
-	case keyNomBattV:
-		s.NominalBatteryVoltage, err = parse()
-	case keyITemp:
-		s.InternalTemp, err = parse()
-	case keyOutV:
-		s.OutputVoltage, err = parse()
-	case keyLineFrequency:
-		s.LineFrequency, err = parse()
-	case keyMaxLineV:
-		s.MaximumLineVoltage, err = parse()
-	case keyMinLineV:
-		s.MinimumLineVoltage, err = parse()
-	case keyDWake:
-		s.WakeDelay, err = parse()
-	case keyDShutD:
-		s.ShutdownDelay, err = parse()
-	case keyDLowBatt:
-		s.LowBatteryDelay, err = parse()
-	case keyRetPct:
-		s.RestorePercent, err = parse()
-	case keyNomOutV:
-		s.NominalOutputVoltage, err = parse()
-	default:
-		return false, nil
-	}
+		d, err := parseDuration(v)
+		if err != nil {
+			return err
+		}
+		s.AlarmDel = d
+		return nil
+	})
 
-	return true, err
+	// Remaining, less common data types.
+	RegisterField(keyNumXfers, func(s *Status, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		s.NumberTransfers = n
+		return nil
+	})
+	RegisterField(keySTestI, func(s *Status, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		s.SelfTestInterval = n
+		return nil
+	})
+	RegisterField(keyExtBatts, func(s *Status, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		s.ExternalBatteries = n
+		return nil
+	})
+	RegisterField(keyBadBatts, func(s *Status, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		s.BadBatteries = n
+		return nil
+	})
+	RegisterField(keyNomPower, func(s *Status, v string) error {
+		f := strings.SplitN(v, " ", 2)
+		n, err := strconv.Atoi(f[0])
+		if err != nil {
+			return err
+		}
+		s.NominalPower = n
+		return nil
+	})
+	RegisterField(keySelftest, func(s *Status, v string) error {
+		s.Selftest = v == "YES"
+		return nil
+	})
 }
 
-// parseKVTime parses a time.Time value into the appropriate Status field. It
-// returns true if a field was matched, and false if not.
-func (s *Status) parseKVTime(k string, v string) (bool, error) {
-	var err error
-	switch k {
-	case keyDate:
-		s.Date, err = parseOptionalTime(v)
-	case keyStartTime:
-		s.StartTime, err = parseOptionalTime(v)
-	case keyXOnBat:
-		s.XOnBattery, err = parseOptionalTime(v)
-	case keyXOffBat:
-		s.XOffBattery, err = parseOptionalTime(v)
-	case keyLastStest:
-		s.LastSelftest, err = parseOptionalTime(v)
-	case keyEndAPC:
-		s.EndAPC, err = parseOptionalTime(v)
-	default:
-		return false, nil
-	}
-
-	return true, err
+// stringField registers a FieldSetter for key that copies the raw value
+// verbatim into the field selected by field.
+func stringField(key string, field func(*Status) *string) {
+	RegisterField(key, func(s *Status, v string) error {
+		*field(s) = v
+		return nil
+	})
 }
 
-// parseKVDuration parses a time.Duration into the appropriate Status field. It
-// returns true if a field was matched, and false if not.
-func (s *Status) parseKVDuration(k string, v string) (bool, error) {
-	// Save repetition for function calls.
-	parse := func() (time.Duration, error) {
-		return parseDuration(v)
-	}
+// floatField registers a FieldSetter for key that parses the first
+// space-separated token of the value as a float64 and stores it in the
+// field selected by field.
+func floatField(key string, field func(*Status) *float64) {
+	RegisterField(key, func(s *Status, v string) error {
+		f, err := parseFloat(v)
+		if err != nil {
+			return err
+		}
+		*field(s) = f
+		return nil
+	})
+}
 
-	var err error
-	switch k {
-	case keyTimeLeft:
-		s.TimeLeft, err = parse()
-	case keyMinTimeL:
-		s.MinimumTimeLeft, err = parse()
-	case keyMaxTime:
-		s.MaximumTime, err = parse()
-	case keyAlarmDel:
-		// No alarm delay configured.
-		if v == "No alarm" {
-			break
+// timeField registers a FieldSetter for key that parses the value as an
+// optional timestamp and stores it in the field selected by field.
+func timeField(key string, field func(*Status) *time.Time) {
+	RegisterField(key, func(s *Status, v string) error {
+		t, err := parseOptionalTime(v)
+		if err != nil {
+			return err
 		}
+		*field(s) = t
+		return nil
+	})
+}
 
-		s.AlarmDel, err = parse()
-	case keyTOnBatt:
-		s.TimeOnBattery, err = parse()
-	case keyCumOnBatt:
-		s.CumulativeTimeOnBattery, err = parse()
-	default:
-		return false, nil
-	}
+// durationField registers a FieldSetter for key that parses the value as a
+// duration and stores it in the field selected by field.
+func durationField(key string, field func(*Status) *time.Duration) {
+	RegisterField(key, func(s *Status, v string) error {
+		d, err := parseDuration(v)
+		if err != nil {
+			return err
+		}
+		*field(s) = d
+		return nil
+	})
+}
 
-	return true, err
+// parseFloat parses the first space-separated token of v as a float64,
+// ignoring any trailing unit such as "Volts" or "Percent".
+func parseFloat(v string) (float64, error) {
+	f := strings.SplitN(v, " ", 2)
+	return strconv.ParseFloat(f[0], 64)
 }
 
 // parseDuration parses a duration value returned from a NIS as a time.Duration.
@@ -0,0 +1,63 @@
+package apcupsd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatusParseKVKnownField(t *testing.T) {
+	var s Status
+	if err := s.parseKV("LINEV : 120.5 Volts"); err != nil {
+		t.Fatalf("parseKV() error = %v", err)
+	}
+	if s.LineVoltage != 120.5 {
+		t.Errorf("LineVoltage = %v, want 120.5", s.LineVoltage)
+	}
+}
+
+func TestStatusParseKVCaseInsensitive(t *testing.T) {
+	var s Status
+	if err := s.parseKV("linev : 100.0 Volts"); err != nil {
+		t.Fatalf("parseKV() error = %v", err)
+	}
+	if s.LineVoltage != 100.0 {
+		t.Errorf("LineVoltage = %v, want 100.0", s.LineVoltage)
+	}
+}
+
+func TestStatusParseKVUnknownFieldGoesToExtra(t *testing.T) {
+	var s Status
+	if err := s.parseKV("WIDGET : whatever"); err != nil {
+		t.Fatalf("parseKV() error = %v", err)
+	}
+	if got, want := s.Extra["WIDGET"], "whatever"; got != want {
+		t.Errorf(`Extra["WIDGET"] = %q, want %q`, got, want)
+	}
+}
+
+func TestRegisterFieldCustomKey(t *testing.T) {
+	const key = "XCUSTOMKEY"
+
+	var gotValue string
+	RegisterField(key, func(s *Status, v string) error {
+		gotValue = v
+		return nil
+	})
+
+	var s Status
+	if err := s.parseKV(key + " : hello"); err != nil {
+		t.Fatalf("parseKV() error = %v", err)
+	}
+	if gotValue != "hello" {
+		t.Errorf("setter received %q, want %q", gotValue, "hello")
+	}
+
+	// Matching against a registered key is case-insensitive too.
+	gotValue = ""
+	if err := s.parseKV(strings.ToLower(key) + " : world"); err != nil {
+		t.Fatalf("parseKV() error = %v", err)
+	}
+	if gotValue != "world" {
+		t.Errorf("setter received %q, want %q", gotValue, "world")
+	}
+}
@@ -0,0 +1,391 @@
+package apcupsd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An EventType identifies the kind of change that produced an Event. The
+// design mirrors etcd's mvccpb.Event/EventType: a small enum describing what
+// kind of change occurred, paired with the data needed to act on it.
+type EventType int
+
+// Possible EventType values produced by a Watcher.
+const (
+	// EventSnapshot is delivered to a newly registered subscriber when
+	// WatcherOptions.ReplayLatest is set and a Status has already been
+	// observed; it carries the most recently known Status so late
+	// subscribers don't have to wait for the next change to catch up.
+	EventSnapshot EventType = iota
+
+	// EventOnBattery indicates the UPS began running on battery power.
+	EventOnBattery
+
+	// EventOffBattery indicates the UPS returned to line power.
+	EventOffBattery
+
+	// EventStatusChanged indicates a change in the UPS's Status string, e.g.
+	// a transition from "ONLINE" to "ONBATT".
+	EventStatusChanged
+
+	// EventBatteryChargeThreshold indicates BatteryChargePercent crossed the
+	// threshold configured in WatcherOptions.ChargeThreshold.
+	EventBatteryChargeThreshold
+
+	// EventSelftestChanged indicates the result of a self test changed.
+	EventSelftestChanged
+
+	// EventTransfer indicates NumberTransfers incremented, meaning the UPS
+	// transferred to or from battery power at least once since the last
+	// poll.
+	EventTransfer
+)
+
+// String returns the string representation of an EventType.
+func (t EventType) String() string {
+	switch t {
+	case EventSnapshot:
+		return "Snapshot"
+	case EventOnBattery:
+		return "OnBattery"
+	case EventOffBattery:
+		return "OffBattery"
+	case EventStatusChanged:
+		return "StatusChanged"
+	case EventBatteryChargeThreshold:
+		return "BatteryChargeThreshold"
+	case EventSelftestChanged:
+		return "SelftestChanged"
+	case EventTransfer:
+		return "Transfer"
+	default:
+		return "unknown"
+	}
+}
+
+// An Event describes a single change observed between two consecutive polls
+// of a NIS.
+type Event struct {
+	// Type is the kind of change that produced this Event.
+	Type EventType
+
+	// Field is the name of the Status field that triggered this Event, e.g.
+	// "Status" or "BatteryChargePercent". It is empty for EventSnapshot.
+	Field string
+
+	// PrevStatus and CurrStatus are the Status values observed immediately
+	// before and after the change, respectively. PrevStatus is nil for
+	// EventSnapshot.
+	PrevStatus, CurrStatus *Status
+}
+
+// WatcherOptions configures the behavior of a Watcher.
+type WatcherOptions struct {
+	// Interval is the amount of time to wait between polls of the NIS. If
+	// zero, a default of 10 seconds is used.
+	Interval time.Duration
+
+	// ChargeThreshold, if nonzero, causes an EventBatteryChargeThreshold
+	// event to be emitted whenever BatteryChargePercent crosses this value
+	// between polls.
+	ChargeThreshold float64
+
+	// ReplayLatest causes newly registered subscribers to immediately
+	// receive an EventSnapshot carrying the most recently observed Status,
+	// rather than waiting for the next change.
+	ReplayLatest bool
+
+	// MaxBackoff caps the delay applied between reconnection attempts after
+	// a connection to a NIS is lost. If zero, a default of one minute is
+	// used.
+	MaxBackoff time.Duration
+
+	// Timeout bounds how long a single poll, including connection setup,
+	// may take. If zero, no timeout is applied.
+	Timeout time.Duration
+
+	// MaxRetries caps the number of consecutive failed polls Run will
+	// tolerate before giving up and returning an error. If zero, Run
+	// retries indefinitely and only returns when ctx is canceled.
+	MaxRetries int
+
+	// TLS, if non-nil, causes Run to connect to the NIS with DialTLS instead
+	// of Dial, using this config. This is how a NIS fronted by stunnel (or
+	// similar) is reached; a stock apcupsd NIS has no TLS of its own.
+	TLS *tls.Config
+}
+
+// A Watcher polls a NIS at a regular interval and emits Events describing
+// changes to Status between polls.
+type Watcher struct {
+	network, address string
+	opts             WatcherOptions
+
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+	last *Status
+	done chan struct{}
+}
+
+// NewWatcher creates a Watcher that will dial the NIS at network and
+// address. Call Run to begin polling.
+func NewWatcher(network, address string, opts WatcherOptions) *Watcher {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Minute
+	}
+
+	return &Watcher{
+		network: network,
+		address: address,
+		opts:    opts,
+		subs:    make(map[chan Event]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Subscribe registers a new subscriber and returns a channel of Events for
+// it. The channel is closed when ctx is canceled or Run returns (including a
+// Run that has already returned before Subscribe is called). Events are
+// delivered on a best-effort basis: a subscriber that isn't keeping up with
+// the channel will miss Events rather than blocking the Watcher.
+func (w *Watcher) Subscribe(ctx context.Context) <-chan Event {
+	ch := make(chan Event, 16)
+
+	w.mu.Lock()
+	select {
+	case <-w.done:
+		w.mu.Unlock()
+		close(ch)
+		return ch
+	default:
+	}
+	w.subs[ch] = struct{}{}
+	last := w.last
+	replay := w.opts.ReplayLatest
+	w.mu.Unlock()
+
+	if replay && last != nil {
+		select {
+		case ch <- Event{Type: EventSnapshot, CurrStatus: last}:
+		default:
+		}
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-w.done:
+		}
+		w.mu.Lock()
+		delete(w.subs, ch)
+		w.mu.Unlock()
+		close(ch)
+	}()
+
+	return ch
+}
+
+// Run polls the NIS at the configured interval until ctx is canceled,
+// publishing Events to every active subscriber. Run blocks until ctx is
+// canceled, at which point it returns ctx.Err(). If the connection to the
+// NIS is lost or cannot be established, Run retries with exponential backoff
+// capped at WatcherOptions.MaxBackoff. If WatcherOptions.MaxRetries is
+// nonzero and that many consecutive polls fail, Run gives up and returns
+// the last error instead of retrying further. Whenever Run returns, for
+// either reason, every channel returned by Subscribe is closed.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer close(w.done)
+
+	backoff := w.opts.Interval
+	var failures int
+
+	for {
+		status, err := w.poll()
+		if err != nil {
+			failures++
+			if w.opts.MaxRetries > 0 && failures >= w.opts.MaxRetries {
+				return fmt.Errorf("apcupsd: giving up after %d consecutive failed polls: %w", failures, err)
+			}
+
+			if backoff > w.opts.MaxBackoff {
+				backoff = w.opts.MaxBackoff
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+			continue
+		}
+		failures = 0
+		backoff = w.opts.Interval
+
+		w.mu.Lock()
+		prev := w.last
+		w.last = status
+		w.mu.Unlock()
+
+		for _, ev := range diffStatus(prev, status, w.opts.ChargeThreshold) {
+			w.publish(ev)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.opts.Interval):
+		}
+	}
+}
+
+// poll dials the NIS, fetches its current Status, and closes the
+// connection.
+func (w *Watcher) poll() (*Status, error) {
+	var (
+		dialer   net.Dialer
+		deadline time.Time
+		conn     net.Conn
+		err      error
+	)
+	if w.opts.Timeout > 0 {
+		deadline = time.Now().Add(w.opts.Timeout)
+		dialer.Deadline = deadline
+	}
+	if w.opts.TLS != nil {
+		conn, err = tls.DialWithDialer(&dialer, w.network, w.address, w.opts.TLS)
+	} else {
+		conn, err = dialer.Dial(w.network, w.address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{conn: conn}
+	defer c.Close()
+
+	if !deadline.IsZero() {
+		if err := c.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.Status()
+}
+
+// Latest returns the most recently observed Status, or nil if Run has not
+// yet completed a successful poll.
+func (w *Watcher) Latest() *Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.last
+}
+
+// publish delivers ev to every active subscriber, dropping it for any
+// subscriber whose channel is full.
+func (w *Watcher) publish(ev Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for ch := range w.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// diffStatus compares prev and curr and returns the Events describing what
+// changed between them. If prev is nil, no Events are produced: there is
+// nothing yet to compare against.
+func diffStatus(prev, curr *Status, chargeThreshold float64) []Event {
+	if prev == nil {
+		return nil
+	}
+
+	var events []Event
+
+	if prev.Status != curr.Status {
+		events = append(events, Event{
+			Type:       EventStatusChanged,
+			Field:      "Status",
+			PrevStatus: prev,
+			CurrStatus: curr,
+		})
+	}
+
+	wasOnBattery := prev.OnBattery()
+	isOnBatt := curr.OnBattery()
+	switch {
+	case !wasOnBattery && isOnBatt:
+		events = append(events, Event{
+			Type:       EventOnBattery,
+			Field:      "Status",
+			PrevStatus: prev,
+			CurrStatus: curr,
+		})
+	case wasOnBattery && !isOnBatt:
+		events = append(events, Event{
+			Type:       EventOffBattery,
+			Field:      "Status",
+			PrevStatus: prev,
+			CurrStatus: curr,
+		})
+	}
+
+	if chargeThreshold != 0 && crossedThreshold(prev.BatteryChargePercent, curr.BatteryChargePercent, chargeThreshold) {
+		events = append(events, Event{
+			Type:       EventBatteryChargeThreshold,
+			Field:      "BatteryChargePercent",
+			PrevStatus: prev,
+			CurrStatus: curr,
+		})
+	}
+
+	if prev.Selftest != curr.Selftest {
+		events = append(events, Event{
+			Type:       EventSelftestChanged,
+			Field:      "Selftest",
+			PrevStatus: prev,
+			CurrStatus: curr,
+		})
+	}
+
+	if curr.NumberTransfers > prev.NumberTransfers {
+		events = append(events, Event{
+			Type:       EventTransfer,
+			Field:      "NumberTransfers",
+			PrevStatus: prev,
+			CurrStatus: curr,
+		})
+	}
+
+	return events
+}
+
+// OnBattery reports whether s indicates the UPS is currently running on
+// battery power. Status can carry multiple space-separated flags at once
+// (e.g. "ONBATT LOWBATT" or "ONBATT OVERLOAD"), so this checks for "ONBATT"
+// among them rather than comparing the whole string.
+func (s *Status) OnBattery() bool {
+	for _, flag := range strings.Fields(s.Status) {
+		if flag == "ONBATT" {
+			return true
+		}
+	}
+	return false
+}
+
+// crossedThreshold reports whether the value moved from one side of
+// threshold to the other between prev and curr.
+func crossedThreshold(prev, curr, threshold float64) bool {
+	return (prev >= threshold) != (curr >= threshold)
+}
@@ -0,0 +1,202 @@
+package apcupsd
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCrossedThreshold(t *testing.T) {
+	tests := []struct {
+		name       string
+		prev, curr float64
+		threshold  float64
+		want       bool
+	}{
+		{"both above threshold", 80, 90, 50, false},
+		{"both below threshold", 10, 20, 50, false},
+		{"crosses upward", 40, 60, 50, true},
+		{"crosses downward", 60, 40, 50, true},
+		{"lands exactly on threshold from below", 40, 50, 50, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := crossedThreshold(tt.prev, tt.curr, tt.threshold); got != tt.want {
+				t.Errorf("crossedThreshold(%v, %v, %v) = %v, want %v", tt.prev, tt.curr, tt.threshold, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev      *Status
+		curr      *Status
+		threshold float64
+		want      []EventType
+	}{
+		{
+			name: "nil prev produces no events",
+			prev: nil,
+			curr: &Status{Status: "ONLINE"},
+			want: nil,
+		},
+		{
+			name: "no change produces no events",
+			prev: &Status{Status: "ONLINE"},
+			curr: &Status{Status: "ONLINE"},
+			want: nil,
+		},
+		{
+			name: "transition to battery power",
+			prev: &Status{Status: "ONLINE"},
+			curr: &Status{Status: "ONBATT"},
+			want: []EventType{EventStatusChanged, EventOnBattery},
+		},
+		{
+			name: "transition off battery power",
+			prev: &Status{Status: "ONBATT"},
+			curr: &Status{Status: "ONLINE"},
+			want: []EventType{EventStatusChanged, EventOffBattery},
+		},
+		{
+			name: "transition to battery power with a combined status flag",
+			prev: &Status{Status: "ONLINE"},
+			curr: &Status{Status: "ONBATT LOWBATT"},
+			want: []EventType{EventStatusChanged, EventOnBattery},
+		},
+		{
+			name:      "battery charge crosses threshold",
+			prev:      &Status{BatteryChargePercent: 60},
+			curr:      &Status{BatteryChargePercent: 40},
+			threshold: 50,
+			want:      []EventType{EventBatteryChargeThreshold},
+		},
+		{
+			name: "selftest result changes",
+			prev: &Status{Selftest: false},
+			curr: &Status{Selftest: true},
+			want: []EventType{EventSelftestChanged},
+		},
+		{
+			name: "number of transfers increments",
+			prev: &Status{NumberTransfers: 1},
+			curr: &Status{NumberTransfers: 2},
+			want: []EventType{EventTransfer},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := diffStatus(tt.prev, tt.curr, tt.threshold)
+			if len(events) != len(tt.want) {
+				t.Fatalf("diffStatus() = %d events, want %d (got %+v)", len(events), len(tt.want), events)
+			}
+
+			for i, wantType := range tt.want {
+				if events[i].Type != wantType {
+					t.Errorf("event %d Type = %v, want %v", i, events[i].Type, wantType)
+				}
+			}
+		})
+	}
+}
+
+func TestWatcherRunEmitsEvents(t *testing.T) {
+	addr := newFakeNIS(t, [][]string{
+		{"STATUS   : ONLINE"},
+		{"STATUS   : ONBATT"},
+	})
+
+	w := NewWatcher("tcp", addr, WatcherOptions{Interval: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	sub := w.Subscribe(ctx)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Run(ctx) }()
+
+	for {
+		select {
+		case ev, ok := <-sub:
+			if !ok {
+				t.Fatal("subscriber channel closed before observing EventOnBattery")
+			}
+			if ev.Type == EventOnBattery {
+				cancel()
+				<-errCh
+				return
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for EventOnBattery")
+		}
+	}
+}
+
+func TestWatcherRunGivesUpAfterMaxRetries(t *testing.T) {
+	// Nothing is listening here, so every poll fails.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	w := NewWatcher("tcp", addr, WatcherOptions{
+		Interval:   5 * time.Millisecond,
+		MaxBackoff: 5 * time.Millisecond,
+		MaxRetries: 3,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err = w.Run(ctx)
+	if err == nil {
+		t.Fatal("Run() error = nil, want a giving-up error")
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("Run() returned after the context timed out instead of giving up: %v", err)
+	}
+}
+
+func TestWatcherSubscribeClosesWhenRunReturns(t *testing.T) {
+	addr := newFakeNIS(t, [][]string{{"STATUS   : ONLINE"}})
+
+	w := NewWatcher("tcp", addr, WatcherOptions{Interval: 10 * time.Millisecond})
+
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	sub := w.Subscribe(context.Background())
+
+	runDone := make(chan struct{})
+	go func() {
+		w.Run(runCtx)
+		close(runDone)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancelRun()
+
+	select {
+	case <-runDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after its context was canceled")
+	}
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case _, ok := <-sub:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("subscriber channel was not closed after Run returned")
+		}
+	}
+}